@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Cipher is implemented by every substitution variant selectable via
+// --variant. Each owns its own tabula recta and keystream schedule; most
+// differ from Vigenere only in which row/column the table_* lookups below
+// use for a given plaintext/key rune pair.
+type Cipher interface {
+	generate(valid_chars string) error
+	encrypt_stream(r io.Reader, w io.Writer, key string, report func(int64)) error
+	decrypt_stream(r io.Reader, w io.Writer, key string, report func(int64)) error
+}
+
+// new_cipher constructs the Cipher implementation named by --variant.
+func new_cipher(variant string) (Cipher, error) {
+	switch variant {
+	case "", "vigenere":
+		return &Vigenere{}, nil
+	case "autokey":
+		return &Autokey{}, nil
+	case "beaufort":
+		return &Beaufort{}, nil
+	case "gronsfeld":
+		return &Gronsfeld{}, nil
+	case "running-key":
+		return &RunningKey{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cipher variant %q (want vigenere, autokey, beaufort, gronsfeld, or running-key)", variant)
+	}
+}
+
+// generate_table builds a tabula recta: row i holds valid_chars rotated left
+// by i positions.
+func generate_table(valid_chars string) []string {
+	table := make([]string, len(valid_chars))
+
+	for i := range valid_chars {
+		table[i] = str_rotate_left(valid_chars, i)
+	}
+
+	return table
+}
+
+func table_available(table []string) bool {
+	return len(table) != 0
+}
+
+// table_substitute is the forward Vigenere lookup: row is the plaintext
+// char's index, column is the keychar's index.
+func table_substitute(table []string, char rune, keychar rune) (rune, error) {
+	if !table_available(table) {
+		return -1, errors.New("no tabula recta generated")
+	}
+
+	row := strings.IndexRune(table[0], char)
+	if row < 0 {
+		return -1, fmt.Errorf("substitute: character '%c' not found in table rows", char)
+	}
+
+	col := strings.IndexRune(table[0], keychar)
+	if col < 0 {
+		return -1, fmt.Errorf("substitute: character '%c' not found in table columns", keychar)
+	}
+
+	return []rune(table[row])[col], nil
+}
+
+// table_reverse_substitute is the inverse Vigenere lookup: row is the
+// keychar's index, and the ciphertext char is located within that row.
+func table_reverse_substitute(table []string, char rune, keychar rune) (rune, error) {
+	if !table_available(table) {
+		return -1, errors.New("no tabula recta generated")
+	}
+
+	row := strings.IndexRune(table[0], keychar)
+	if row < 0 {
+		return -1, fmt.Errorf("reverse_substitute: character '%c' not found in table rows", keychar)
+	}
+
+	col := strings.IndexRune(table[row], char)
+	if col < 0 {
+		return -1, fmt.Errorf("reverse_substitute: character '%c' not found in table columns", char)
+	}
+
+	return []rune(table[0])[col], nil
+}
+
+// table_substitute_shift is table_substitute with the keychar already
+// resolved to an integer column index, for variants (Gronsfeld) whose key
+// symbols aren't themselves alphabet characters.
+func table_substitute_shift(table []string, char rune, shift int) (rune, error) {
+	if !table_available(table) {
+		return -1, errors.New("no tabula recta generated")
+	}
+	if shift < 0 || shift >= len(table) {
+		return -1, fmt.Errorf("substitute_shift: shift %d out of range", shift)
+	}
+
+	row := strings.IndexRune(table[0], char)
+	if row < 0 {
+		return -1, fmt.Errorf("substitute_shift: character '%c' not found in table rows", char)
+	}
+
+	return []rune(table[row])[shift], nil
+}
+
+// table_index returns char's position in the alphabet (row 0 of table).
+func table_index(table []string, char rune) (int, error) {
+	idx := strings.IndexRune(table[0], char)
+	if idx < 0 {
+		return -1, fmt.Errorf("character '%c' not found in alphabet", char)
+	}
+	return idx, nil
+}
+
+// table_beaufort computes the Beaufort substitution (keychar - char) mod N,
+// which is its own inverse: table_beaufort(table_beaufort(char, k), k) == char.
+func table_beaufort(table []string, char rune, keychar rune) (rune, error) {
+	if !table_available(table) {
+		return -1, errors.New("no tabula recta generated")
+	}
+
+	a, err := table_index(table, char)
+	if err != nil {
+		return -1, err
+	}
+	b, err := table_index(table, keychar)
+	if err != nil {
+		return -1, err
+	}
+
+	n := len(table)
+	idx := ((b-a)%n + n) % n
+
+	return []rune(table[0])[idx], nil
+}
+
+// table_reverse_substitute_shift is table_reverse_substitute with the
+// keychar already resolved to an integer row index.
+func table_reverse_substitute_shift(table []string, char rune, shift int) (rune, error) {
+	if !table_available(table) {
+		return -1, errors.New("no tabula recta generated")
+	}
+	if shift < 0 || shift >= len(table) {
+		return -1, fmt.Errorf("reverse_substitute_shift: shift %d out of range", shift)
+	}
+
+	col := strings.IndexRune(table[shift], char)
+	if col < 0 {
+		return -1, fmt.Errorf("reverse_substitute_shift: character '%c' not found in table columns", char)
+	}
+
+	return []rune(table[0])[col], nil
+}
+
+// generic_substitute_stream reads r in fixed-size chunks, substitutes each
+// byte against keystring cycled across the entire stream, and writes the
+// result to w. It backs every variant whose keystream is just the key
+// repeated (Vigenere, Beaufort, Gronsfeld); Autokey and RunningKey build the
+// keystream differently and so implement their own loop.
+func generic_substitute_stream(r io.Reader, w io.Writer, keystring string, report func(int64), substitute func(char rune, keychar rune) (rune, error)) error {
+	if len(keystring) == 0 {
+		return errors.New("empty key")
+	}
+
+	buf := make([]byte, stream_chunk_size)
+	out := make([]byte, stream_chunk_size)
+	key_pos := 0
+	var written int64
+
+	for {
+		n, read_err := r.Read(buf)
+		if n > 0 {
+			for i := 0; i < n; i++ {
+				keychar := rune(keystring[key_pos%len(keystring)])
+				tmp, err := substitute(rune(buf[i]), keychar)
+				if err != nil {
+					return err
+				}
+				out[i] = byte(tmp)
+				key_pos++
+			}
+
+			if _, err := w.Write(out[:n]); err != nil {
+				return err
+			}
+
+			written += int64(n)
+			if report != nil {
+				report(written)
+			}
+		}
+
+		if read_err == io.EOF {
+			return nil
+		}
+		if read_err != nil {
+			return read_err
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// new_root_command builds the go-vigenere command tree: encrypt, decrypt,
+// and crack, each with its own flags and help text provided by cobra.
+func new_root_command() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "go-vigenere",
+		Short:         "Encrypt, decrypt, and crack classical polyalphabetic ciphers",
+		Long:          DESCRIPTION,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+	}
+
+	root.AddCommand(new_encrypt_command())
+	root.AddCommand(new_decrypt_command())
+	root.AddCommand(new_crack_command())
+	root.AddCommand(new_keygen_command())
+
+	return root
+}
+
+func new_encrypt_command() *cobra.Command {
+	return new_cipher_command("encrypt", false)
+}
+
+func new_decrypt_command() *cobra.Command {
+	return new_cipher_command("decrypt", true)
+}
+
+// new_cipher_command builds the encrypt/decrypt command shared by every
+// --variant: classic streaming mode by default, or the --armored envelope
+// format (--variant=vigenere only).
+func new_cipher_command(name string, decrypting bool) *cobra.Command {
+	var key, input_path, output_path, variant string
+	var armored, paranoid, mnemonic bool
+	var argon2_time, argon2_mem uint
+	var reed_solomon, rs_fix, rs_keep bool
+	var rs_required, rs_total, rs_block_size int
+
+	verb := "Encipher"
+	if decrypting {
+		verb = "Decipher"
+	}
+
+	cmd := &cobra.Command{
+		Use:   name + " [key] [file]",
+		Short: fmt.Sprintf("%s text from a file or stream with a specified key", verb),
+		Args:  cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 2 {
+				// Backward-compatible positional form: `<key> <file>`.
+				key, input_path = args[0], args[1]
+			}
+			if key == "" {
+				return fmt.Errorf("expected a key via -k (or the positional `%s <key> <file>` form)", name)
+			}
+
+			if mnemonic {
+				if armored {
+					return fmt.Errorf("--mnemonic is not supported with --armored; the passphrase is already stretched via Argon2id")
+				}
+
+				derived, err := derive_key_from_mnemonic(key, mnemonic_key_length, ALPHABET)
+				if err != nil {
+					return err
+				}
+				key = derived
+			}
+
+			if armored {
+				if variant != "" && variant != "vigenere" {
+					return fmt.Errorf("--armored is only supported for --variant=vigenere")
+				}
+				if reed_solomon || rs_fix || rs_keep {
+					return fmt.Errorf("--armored already authenticates its ciphertext; it cannot be combined with --reed-solomon/--fix/--keep")
+				}
+
+				var vigenere Vigenere
+				if err := vigenere.generate(ALPHABET); err != nil {
+					return err
+				}
+
+				params := resolve_argon2_params(argon2_time, argon2_mem, paranoid)
+				run_envelope_command(vigenere, decrypting, key, input_path, output_path, params)
+				return nil
+			}
+
+			cipher, err := new_cipher(variant)
+			if err != nil {
+				return err
+			}
+
+			var rs_params *ReedSolomonParams
+			if reed_solomon {
+				rs_params = &ReedSolomonParams{BlockSize: rs_block_size, Required: rs_required, Total: rs_total}
+			}
+
+			run_cipher_stream_command(cipher, key, input_path, output_path, decrypting, rs_params, RSDecodeOptions{Fix: rs_fix, Keep: rs_keep})
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&key, "key", "k", "", "encryption key, or passphrase in --armored mode")
+	cmd.Flags().StringVarP(&input_path, "input", "i", "-", "input file, or - for stdin")
+	cmd.Flags().StringVarP(&output_path, "output", "o", "-", "output file, or - for stdout")
+	cmd.Flags().StringVar(&variant, "variant", "vigenere", "cipher variant: vigenere, autokey, beaufort, gronsfeld, running-key")
+	cmd.Flags().BoolVar(&armored, "armored", false, "use the authenticated, key-stretched envelope format")
+	cmd.Flags().BoolVar(&armored, "v2", false, "alias for --armored")
+	cmd.Flags().UintVar(&argon2_time, "argon2-time", 0, "Argon2id time cost (default 3, or 8 with --paranoid)")
+	cmd.Flags().UintVar(&argon2_mem, "argon2-mem", 0, "Argon2id memory cost in MiB (default 65536, or 524288 with --paranoid)")
+	cmd.Flags().BoolVar(&paranoid, "paranoid", false, "use stronger (slower) Argon2id parameters")
+	cmd.Flags().BoolVar(&mnemonic, "mnemonic", false, "treat the key as a 12/18/24-word mnemonic phrase (see `keygen`) instead of raw key text")
+
+	if decrypting {
+		cmd.Flags().BoolVar(&rs_fix, "fix", false, "if the ciphertext is Reed-Solomon protected, attempt error correction and report repaired blocks")
+		cmd.Flags().BoolVar(&rs_keep, "keep", false, "with --fix, emit a best-effort block instead of failing when it is unrecoverable")
+	} else {
+		cmd.Flags().BoolVarP(&reed_solomon, "reed-solomon", "r", false, "protect the ciphertext with Reed-Solomon error correction")
+		cmd.Flags().IntVar(&rs_required, "rs-required", default_reed_solomon_params.Required, "Reed-Solomon data shares per block")
+		cmd.Flags().IntVar(&rs_total, "rs-total", default_reed_solomon_params.Total, "Reed-Solomon total shares per block (rs-total - rs-required is the parity budget)")
+		cmd.Flags().IntVar(&rs_block_size, "rs-block-size", default_reed_solomon_params.BlockSize, "Reed-Solomon block size in bytes")
+	}
+
+	return cmd
+}
+
+// new_keygen_command builds the keygen subcommand, which prints a random
+// checksum-protected mnemonic phrase suitable for --mnemonic.
+func new_keygen_command() *cobra.Command {
+	var word_count int
+
+	cmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Generate a random key as a human-transcribable mnemonic phrase",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			phrase, err := generate_mnemonic(word_count)
+			if err != nil {
+				return err
+			}
+			fmt.Println(phrase)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&word_count, "words", 12, "mnemonic length: 12, 18, or 24 words")
+
+	return cmd
+}
+
+func new_crack_command() *cobra.Command {
+	var min_keylen, max_keylen, top int
+	var lang string
+
+	cmd := &cobra.Command{
+		Use:   "crack <ciphertext-file>",
+		Short: "Recover the key and plaintext from a ciphertext file alone",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			var vigenere Vigenere
+			if err := vigenere.generate(ALPHABET); err != nil {
+				return err
+			}
+
+			candidates, err := crack(vigenere, string(data), CrackOptions{
+				MinKeyLen: min_keylen,
+				MaxKeyLen: max_keylen,
+				Lang:      lang,
+				Top:       top,
+			})
+			if err != nil {
+				return err
+			}
+
+			for i, candidate := range candidates {
+				fmt.Printf("#%d  key length %d  (score %.5f)\n", i+1, candidate.KeyLen, candidate.Score)
+				fmt.Printf("key:       %s\n", candidate.Key)
+				fmt.Printf("plaintext: %s\n\n", candidate.Plaintext)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&min_keylen, "min-keylen", 1, "smallest key length to consider")
+	cmd.Flags().IntVar(&max_keylen, "max-keylen", 40, "largest key length to consider")
+	cmd.Flags().StringVar(&lang, "lang", "english", "bundled letter-frequency table to score candidates against (english, spanish)")
+	cmd.Flags().IntVar(&top, "top", 1, "number of best key-length candidates to print")
+
+	return cmd
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// english_frequencies holds the expected relative frequency of each letter
+// A-Z in typical English prose, used as the expected distribution for the
+// chi-squared statistic during key recovery.
+var english_frequencies = [26]float64{
+	0.08167, 0.01492, 0.02782, 0.04253, 0.12702, 0.02228, 0.02015,
+	0.06094, 0.06966, 0.00153, 0.00772, 0.04025, 0.02406, 0.06749,
+	0.07507, 0.01929, 0.00095, 0.05987, 0.06327, 0.09056, 0.02758,
+	0.00978, 0.02360, 0.00150, 0.01974, 0.00074,
+}
+
+// spanish_frequencies holds the expected relative frequency of each letter
+// A-Z in typical Spanish prose.
+var spanish_frequencies = [26]float64{
+	0.12525, 0.01421, 0.04019, 0.05010, 0.13680, 0.00692, 0.01760,
+	0.00703, 0.06247, 0.00493, 0.00011, 0.04967, 0.03157, 0.06712,
+	0.08683, 0.02510, 0.00877, 0.06871, 0.07977, 0.04632, 0.02927,
+	0.01138, 0.00017, 0.00215, 0.01008, 0.00467,
+}
+
+// language_frequencies maps a --lang flag value to its bundled letter
+// frequency table.
+var language_frequencies = map[string][26]float64{
+	"english": english_frequencies,
+	"spanish": spanish_frequencies,
+}
+
+// CrackOptions configures automated Vigenere cryptanalysis.
+type CrackOptions struct {
+	MinKeyLen int
+	MaxKeyLen int
+	Lang      string
+	Top       int
+}
+
+// CrackCandidate is a single key-length guess and the key/plaintext it
+// produced, ordered by how closely its coset Index of Coincidence matches
+// the target language.
+type CrackCandidate struct {
+	KeyLen    int
+	Score     float64
+	Key       string
+	Plaintext string
+}
+
+// letter_shift returns char's case-insensitive position in the English
+// alphabet (0 for 'A'/'a', ..., 25 for 'Z'/'z') and whether char is a letter.
+func letter_shift(char rune) (int, bool) {
+	switch {
+	case char >= 'A' && char <= 'Z':
+		return int(char - 'A'), true
+	case char >= 'a' && char <= 'z':
+		return int(char - 'a'), true
+	default:
+		return 0, false
+	}
+}
+
+// gcd returns the greatest common divisor of a and b.
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// kasiski_gaps finds every repeated substring of length >= 3 in ciphertext
+// and returns the gap between each pair of successive occurrences. A
+// repeated substring caused by the key repeating (rather than coincidence)
+// recurs at a gap that is a multiple of the key length.
+func kasiski_gaps(ciphertext string) []int {
+	const min_substr_len = 3
+
+	positions := make(map[string][]int)
+	for i := 0; i+min_substr_len <= len(ciphertext); i++ {
+		substr := ciphertext[i : i+min_substr_len]
+		positions[substr] = append(positions[substr], i)
+	}
+
+	var gaps []int
+	for _, occurrences := range positions {
+		if len(occurrences) < 2 {
+			continue
+		}
+		for i := 1; i < len(occurrences); i++ {
+			gaps = append(gaps, occurrences[i]-occurrences[i-1])
+		}
+	}
+
+	return gaps
+}
+
+// kasiski_examination pairs up every gap kasiski_gaps finds and tallies how
+// often each pairwise GCD (2..40) results, along with the total number of
+// pairs considered. Tallying raw divisors of each individual gap would bias
+// the histogram toward small numbers for free, since every gap divisible by
+// the true key length L is also divisible by each of L's own divisors. The
+// GCD of two gaps that are both genuine multiples of L (aL and bL) is
+// instead L*gcd(a,b) - always a multiple of L itself, never one of its
+// proper divisors - so this histogram concentrates on L (and, more weakly,
+// its multiples) rather than on L's factors.
+func kasiski_examination(ciphertext string) (map[int]int, int) {
+	const max_divisor = 40
+
+	gaps := kasiski_gaps(ciphertext)
+
+	histogram := make(map[int]int)
+	pairs := 0
+	for i := 0; i < len(gaps); i++ {
+		for j := i + 1; j < len(gaps); j++ {
+			pairs++
+			if g := gcd(gaps[i], gaps[j]); g >= 2 && g <= max_divisor {
+				histogram[g]++
+			}
+		}
+	}
+
+	return histogram, pairs
+}
+
+// index_of_coincidence computes the Index of Coincidence of text, counting
+// only its letters (case-insensitive).
+func index_of_coincidence(text string) float64 {
+	var counts [26]int
+	n := 0
+
+	for _, char := range text {
+		if shift, ok := letter_shift(char); ok {
+			counts[shift]++
+			n++
+		}
+	}
+
+	if n < 2 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, c := range counts {
+		sum += float64(c * (c - 1))
+	}
+
+	return sum / float64(n*(n-1))
+}
+
+// average_ioc splits ciphertext into key_len cosets (coset i holds every
+// character at position i mod key_len) and returns the mean Index of
+// Coincidence across the cosets.
+func average_ioc(ciphertext string, key_len int) float64 {
+	cosets := make([]string, key_len)
+	runes := []rune(ciphertext)
+
+	for i, r := range runes {
+		coset := i % key_len
+		cosets[coset] += string(r)
+	}
+
+	total := 0.0
+	for _, coset := range cosets {
+		total += index_of_coincidence(coset)
+	}
+
+	return total / float64(key_len)
+}
+
+// estimate_key_lengths ranks every key length in [min_len, max_len] by how
+// close its average coset IoC is to the target language's IoC (~0.0667 for
+// English), giving a boost to lengths Kasiski examination also flagged.
+func estimate_key_lengths(ciphertext string, min_len int, max_len int, target_ioc float64) []int {
+	histogram, _ := kasiski_examination(ciphertext)
+
+	type scored struct {
+		keyLen int
+		diff   float64
+	}
+
+	var candidates []scored
+	for l := min_len; l <= max_len; l++ {
+		diff := average_ioc(ciphertext, l) - target_ioc
+		if diff < 0 {
+			diff = -diff
+		}
+		if histogram[l] > 0 {
+			// Kasiski examination agrees with this length: favour it.
+			diff /= float64(1 + histogram[l])
+		}
+		candidates = append(candidates, scored{keyLen: l, diff: diff})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].diff < candidates[j].diff
+	})
+
+	lengths := make([]int, len(candidates))
+	for i, c := range candidates {
+		lengths[i] = c.keyLen
+	}
+
+	return lengths
+}
+
+// space_frequency and other_frequency are the expected share of a coset's
+// non-letter characters in typical prose over this tool's alphabet: spaces
+// between words are common, while digits and punctuation are rare. Folding
+// both into the chi-squared test (rather than discarding non-letters
+// entirely) matters because the remaining bucket, "letters", isn't a fixed
+// sample size: it varies per candidate shift, and without these two buckets
+// a wrong shift that happens to decrypt onto mostly digits/punctuation is
+// scored on a deceptively small, cherry-picked sample instead of being
+// penalized for it.
+const space_frequency = 0.18
+const other_frequency = 0.02
+
+// chi_squared scores how well a coset's observed letter/space/other counts
+// fit the expected distribution, scaled by the coset's full length (not the
+// number of letters a given shift happened to decrypt, which varies per
+// shift and would otherwise let a wrong shift win on sample size alone).
+func chi_squared(observed [26]int, spaces int, other int, expected [26]float64, total int) float64 {
+	letter_share := 1 - space_frequency - other_frequency
+	sum := 0.0
+
+	for i := range observed {
+		exp := expected[i] * letter_share * float64(total)
+		if exp == 0 {
+			continue
+		}
+		diff := float64(observed[i]) - exp
+		sum += diff * diff / exp
+	}
+
+	exp_spaces := space_frequency * float64(total)
+	diff := float64(spaces) - exp_spaces
+	sum += diff * diff / exp_spaces
+
+	exp_other := other_frequency * float64(total)
+	diff = float64(other) - exp_other
+	sum += diff * diff / exp_other
+
+	return sum
+}
+
+// recover_coset_shift tries every possible key shift against a single coset
+// and returns the shift whose decrypted characters best fit the expected
+// language frequencies.
+func recover_coset_shift(v Vigenere, coset string, expected [26]float64) int {
+	coset_len := len([]rune(coset))
+	if coset_len == 0 {
+		return 0
+	}
+
+	best_shift := 0
+	best_score := -1.0
+
+	for shift := 0; shift < len(v.table); shift++ {
+		var observed [26]int
+		spaces, other := 0, 0
+
+		for _, char := range coset {
+			plain, err := v.reverse_substitute_shift(char, shift)
+			if err != nil {
+				continue
+			}
+			if letter, ok := letter_shift(plain); ok {
+				observed[letter]++
+			} else if plain == ' ' {
+				spaces++
+			} else {
+				other++
+			}
+		}
+
+		score := chi_squared(observed, spaces, other, expected, coset_len)
+		if best_score < 0 || score < best_score {
+			best_score = score
+			best_shift = shift
+		}
+	}
+
+	return best_shift
+}
+
+// recover_key finds, for each coset of a given key length, the shift that
+// best matches expected, and concatenates them into a candidate key.
+func recover_key(v Vigenere, ciphertext string, key_len int, expected [26]float64) string {
+	runes := []rune(ciphertext)
+	cosets := make([]string, key_len)
+
+	for i, r := range runes {
+		coset := i % key_len
+		cosets[coset] += string(r)
+	}
+
+	alphabet := []rune(v.table[0])
+	key := make([]rune, key_len)
+
+	for i, coset := range cosets {
+		shift := recover_coset_shift(v, coset, expected)
+		key[i] = alphabet[shift]
+	}
+
+	return string(key)
+}
+
+// crack runs Kasiski examination and the Index of Coincidence test to guess
+// the Vigenere key length(s) used to produce ciphertext, then recovers a key
+// and plaintext for each of the opts.Top best-scoring lengths.
+func crack(v Vigenere, ciphertext string, opts CrackOptions) ([]CrackCandidate, error) {
+	expected, ok := language_frequencies[opts.Lang]
+	if !ok {
+		return nil, fmt.Errorf("crack: unknown language %q", opts.Lang)
+	}
+
+	if opts.MinKeyLen < 1 {
+		return nil, fmt.Errorf("crack: --min-keylen must be at least 1")
+	}
+	if opts.MaxKeyLen < opts.MinKeyLen {
+		return nil, fmt.Errorf("crack: --max-keylen must be >= --min-keylen")
+	}
+
+	const english_ioc = 0.0667
+
+	lengths := estimate_key_lengths(ciphertext, opts.MinKeyLen, opts.MaxKeyLen, english_ioc)
+
+	top := opts.Top
+	if top < 1 {
+		top = 1
+	}
+	if top > len(lengths) {
+		top = len(lengths)
+	}
+
+	candidates := make([]CrackCandidate, 0, top)
+	for _, key_len := range lengths[:top] {
+		key := recover_key(v, ciphertext, key_len, expected)
+
+		plaintext, err := v.decrypt(ciphertext, key)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates = append(candidates, CrackCandidate{
+			KeyLen:    key_len,
+			Score:     average_ioc(ciphertext, key_len),
+			Key:       key,
+			Plaintext: plaintext,
+		})
+	}
+
+	return candidates, nil
+}
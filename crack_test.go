@@ -0,0 +1,173 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func crack_fixture(t *testing.T) Vigenere {
+	t.Helper()
+
+	var v Vigenere
+	if err := v.generate(ALPHABET); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	return v
+}
+
+// long_plaintext is long enough to give Kasiski examination and the Index of
+// Coincidence test enough repeated trigrams and letter statistics to work
+// with.
+const long_plaintext = `THE QUICK BROWN FOX JUMPS OVER THE LAZY DOG WHILE THE QUICK BROWN FOX ` +
+	`WATCHES FROM THE HILL AND THE LAZY DOG SLEEPS IN THE SUN THE QUICK BROWN FOX ` +
+	`RUNS AGAIN THROUGH THE FOREST WHILE THE LAZY DOG DREAMS OF CHASING THE QUICK ` +
+	`BROWN FOX ACROSS THE MEADOW AND THROUGH THE TREES`
+
+// longer_plaintext gives longer-key crack tests enough letters per coset for
+// reliable chi-squared shift recovery and stable Index of Coincidence
+// statistics.
+const longer_plaintext = `IT WAS THE BEST OF TIMES IT WAS THE WORST OF TIMES IT WAS THE AGE OF WISDOM ` +
+	`IT WAS THE AGE OF FOOLISHNESS IT WAS THE EPOCH OF BELIEF IT WAS THE EPOCH ` +
+	`OF INCREDULITY IT WAS THE SEASON OF LIGHT IT WAS THE SEASON OF DARKNESS IT ` +
+	`WAS THE SPRING OF HOPE IT WAS THE WINTER OF DESPAIR WE HAD EVERYTHING ` +
+	`BEFORE US WE HAD NOTHING BEFORE US WE WERE ALL GOING DIRECT TO HEAVEN WE ` +
+	`WERE ALL GOING DIRECT THE OTHER WAY IN SHORT THE PERIOD WAS SO FAR LIKE THE ` +
+	`PRESENT PERIOD THAT SOME OF ITS NOISIEST AUTHORITIES INSISTED ON ITS BEING ` +
+	`RECEIVED FOR GOOD OR FOR EVIL IN THE SUPERLATIVE DEGREE OF COMPARISON ONLY ` +
+	`THERE WERE A KING WITH A LARGE JAW AND A QUEEN WITH A PLAIN FACE ON THE ` +
+	`THRONE OF ENGLAND THERE WERE A KING WITH A LARGE JAW AND A QUEEN WITH A ` +
+	`FAIR FACE ON THE THRONE OF FRANCE IN BOTH COUNTRIES IT WAS CLEARER THAN ` +
+	`CRYSTAL TO THE LORDS OF THE STATE PRESERVES OF LOAVES AND FISHES THAT ` +
+	`THINGS IN GENERAL WERE SETTLED FOR EVER IT WAS THE YEAR OF OUR LORD ONE ` +
+	`THOUSAND SEVEN HUNDRED AND SEVENTY FIVE SPIRITUAL REVELATIONS WERE CONCEDED ` +
+	`TO ENGLAND AT THAT FAVOURED PERIOD AS AT THIS MRS SOUTHCOTT HAD RECENTLY ` +
+	`ATTAINED HER FIVE AND TWENTIETH BLESSED BIRTHDAY OF WHOM A PROPHETIC ` +
+	`PRIVATE IN THE LIFE GUARDS HAD HERALDED THE SUBLIME APPEARANCE BY ` +
+	`ANNOUNCING THAT ARRANGEMENTS WERE MADE FOR THE SWALLOWING UP OF LONDON AND ` +
+	`WESTMINSTER EVEN THE COCK LANE GHOST HAD BEEN LAID ONLY A ROUND DOZEN OF ` +
+	`YEARS AFTER RAPPING OUT ITS MESSAGES AS THE SPIRITS OF THIS VERY YEAR LAST ` +
+	`PAST SUPERNATURALLY DEFICIENT IN ORIGINALITY RAPPED OUT THEIRS MERE ` +
+	`MESSAGES IN THE EARTHLY ORDER OF EVENTS HAD LATELY COME TO THE ENGLISH ` +
+	`CROWN AND PEOPLE FROM A CONGRESS OF BRITISH SUBJECTS IN AMERICA WHICH ` +
+	`STRANGE TO RELATE HAVE PROVED MORE IMPORTANT TO THE HUMAN RACE THAN ANY ` +
+	`COMMUNICATIONS YET RECEIVED THROUGH ANY OF THE CHICKENS OF THE COCK LANE ` +
+	`BROOD`
+
+func TestCrackRecoversShortKey(t *testing.T) {
+	v := crack_fixture(t)
+
+	for _, key := range []string{"KEY", "SECRET", "CIPHER"} {
+		ciphertext, err := v.encrypt(long_plaintext, key)
+		if err != nil {
+			t.Fatalf("encrypt(%q): %v", key, err)
+		}
+
+		candidates, err := crack(v, ciphertext, CrackOptions{
+			MinKeyLen: 1,
+			MaxKeyLen: 12,
+			Lang:      "english",
+			Top:       1,
+		})
+		if err != nil {
+			t.Fatalf("crack(%q): %v", key, err)
+		}
+		if len(candidates) != 1 {
+			t.Fatalf("crack(%q): expected 1 candidate, got %d", key, len(candidates))
+		}
+
+		got := candidates[0]
+		if got.KeyLen != len(key) {
+			t.Errorf("crack(%q): key length = %d, want %d", key, got.KeyLen, len(key))
+		}
+		if got.Key != key {
+			t.Errorf("crack(%q): key = %q, want %q", key, got.Key, key)
+		}
+		if got.Plaintext != long_plaintext {
+			t.Errorf("crack(%q): plaintext mismatch", key)
+		}
+	}
+}
+
+func TestCrackRecoversCompositeLengthKey(t *testing.T) {
+	v := crack_fixture(t)
+
+	// Key lengths with small factors (4 and 2 both divide 8) are the
+	// regression case for the divisor bias in estimate_key_lengths: every
+	// Kasiski gap divisible by 8 is also divisible by 4 and 2, so a naive
+	// histogram count alone ranks the smaller divisors above the true length.
+	for _, key := range []string{"COMPUTER", "BLUEPRINT"} {
+		ciphertext, err := v.encrypt(longer_plaintext, key)
+		if err != nil {
+			t.Fatalf("encrypt(%q): %v", key, err)
+		}
+
+		candidates, err := crack(v, ciphertext, CrackOptions{
+			MinKeyLen: 1,
+			MaxKeyLen: 12,
+			Lang:      "english",
+			Top:       1,
+		})
+		if err != nil {
+			t.Fatalf("crack(%q): %v", key, err)
+		}
+		if len(candidates) != 1 {
+			t.Fatalf("crack(%q): expected 1 candidate, got %d", key, len(candidates))
+		}
+
+		got := candidates[0]
+		if got.KeyLen != len(key) {
+			t.Errorf("crack(%q): key length = %d, want %d", key, got.KeyLen, len(key))
+		}
+		if got.Key != key {
+			t.Errorf("crack(%q): key = %q, want %q", key, got.Key, key)
+		}
+		if got.Plaintext != longer_plaintext {
+			t.Errorf("crack(%q): plaintext mismatch", key)
+		}
+	}
+}
+
+func TestCrackTopReturnsMultipleCandidates(t *testing.T) {
+	v := crack_fixture(t)
+
+	ciphertext, err := v.encrypt(long_plaintext, "SECRET")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	candidates, err := crack(v, ciphertext, CrackOptions{
+		MinKeyLen: 1,
+		MaxKeyLen: 12,
+		Lang:      "english",
+		Top:       5,
+	})
+	if err != nil {
+		t.Fatalf("crack: %v", err)
+	}
+	if len(candidates) != 5 {
+		t.Fatalf("expected 5 candidates, got %d", len(candidates))
+	}
+
+	found := false
+	for _, c := range candidates {
+		if c.KeyLen == len("SECRET") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("expected key length %d among top candidates", len("SECRET"))
+	}
+}
+
+func TestCrackRejectsUnknownLanguage(t *testing.T) {
+	v := crack_fixture(t)
+
+	_, err := crack(v, long_plaintext, CrackOptions{MinKeyLen: 1, MaxKeyLen: 5, Lang: "klingon", Top: 1})
+	if err == nil {
+		t.Fatal("expected error for unknown language")
+	}
+	if !strings.Contains(err.Error(), "klingon") {
+		t.Errorf("error = %q, want it to mention the unknown language", err.Error())
+	}
+}
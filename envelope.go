@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Envelope format (all integers big-endian):
+//
+//	magic(4) || version(1) || salt(16) || time(4) || memory(4) || parallelism(1) || mac(32) || ciphertext(...)
+//
+// mac authenticates version || salt || time || memory || parallelism || ciphertext.
+const (
+	envelope_magic      = "VGN2"
+	envelope_version    = byte(1)
+	envelope_salt_size  = 16
+	envelope_mac_size   = 32
+	envelope_header_len = len(envelope_magic) + 1 + envelope_salt_size + 4 + 4 + 1 + envelope_mac_size
+)
+
+// Argon2Params are the Argon2id cost parameters recorded in an envelope's
+// header so a decrypting party can reproduce the same key stretching.
+type Argon2Params struct {
+	Time        uint32
+	MemoryKiB   uint32
+	Parallelism uint8
+}
+
+// default_argon2_params and paranoid_argon2_params mirror the "normal" and
+// "--paranoid" presets used by tools like Picocrypt.
+var default_argon2_params = Argon2Params{Time: 3, MemoryKiB: 64 * 1024, Parallelism: 4}
+var paranoid_argon2_params = Argon2Params{Time: 8, MemoryKiB: 512 * 1024, Parallelism: 8}
+
+// resolve_argon2_params applies --argon2-time/--argon2-mem overrides on top
+// of the --paranoid preset (or the default preset, if --paranoid wasn't
+// given).
+func resolve_argon2_params(time_cost uint, memory_mib uint, paranoid bool) Argon2Params {
+	params := default_argon2_params
+	if paranoid {
+		params = paranoid_argon2_params
+	}
+
+	if time_cost > 0 {
+		params.Time = uint32(time_cost)
+	}
+	if memory_mib > 0 {
+		params.MemoryKiB = uint32(memory_mib) * 1024
+	}
+
+	return params
+}
+
+// derive_mac_key stretches passphrase into a 32-byte HMAC key via Argon2id,
+// domain-separated from the Vigenere keystream derivation below.
+func derive_mac_key(passphrase string, salt []byte, params Argon2Params) []byte {
+	domain_salt := append(append([]byte{}, salt...), "go-vigenere-mac"...)
+	return argon2.IDKey([]byte(passphrase), domain_salt, params.Time, params.MemoryKiB, params.Parallelism, 32)
+}
+
+// derive_vigenere_key stretches passphrase into a one-time-pad-like Vigenere
+// key exactly length runes long, drawn from the given alphabet. It expands
+// Argon2id output block by block (keyed on an incrementing counter) and
+// rejection-samples each byte to avoid modulo bias when mapping it onto the
+// alphabet.
+func derive_vigenere_key(passphrase string, salt []byte, params Argon2Params, length int, alphabet string) string {
+	alphabet_len := len(alphabet)
+	reject_above := byte(256 - (256 % alphabet_len))
+
+	key := make([]byte, 0, length)
+	for block := uint32(0); len(key) < length; block++ {
+		domain_salt := append(append([]byte{}, salt...), "go-vigenere-key"...)
+		domain_salt = binary.BigEndian.AppendUint32(domain_salt, block)
+
+		candidate := argon2.IDKey([]byte(passphrase), domain_salt, params.Time, params.MemoryKiB, params.Parallelism, 64)
+		for _, b := range candidate {
+			if b >= reject_above {
+				continue
+			}
+			key = append(key, alphabet[int(b)%alphabet_len])
+			if len(key) == length {
+				break
+			}
+		}
+	}
+
+	return string(key)
+}
+
+// compute_envelope_mac computes HMAC-BLAKE2b over the envelope's header
+// fields and ciphertext.
+func compute_envelope_mac(mac_key []byte, version byte, salt []byte, params Argon2Params, ciphertext []byte) []byte {
+	mac := hmac.New(func() hash.Hash {
+		h, _ := blake2b.New256(nil)
+		return h
+	}, mac_key)
+
+	mac.Write([]byte{version})
+	mac.Write(salt)
+	binary.Write(mac, binary.BigEndian, params.Time)
+	binary.Write(mac, binary.BigEndian, params.MemoryKiB)
+	mac.Write([]byte{params.Parallelism})
+	mac.Write(ciphertext)
+
+	return mac.Sum(nil)
+}
+
+// seal_envelope encrypts plaintext under a passphrase-derived keystream and
+// wraps it in an authenticated envelope.
+func seal_envelope(v Vigenere, plaintext []byte, passphrase string, params Argon2Params) ([]byte, error) {
+	salt := make([]byte, envelope_salt_size)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("seal_envelope: %w", err)
+	}
+
+	key := derive_vigenere_key(passphrase, salt, params, len(plaintext), v.table[0])
+	ciphertext, err := v.encrypt(string(plaintext), key)
+	if err != nil {
+		return nil, err
+	}
+
+	mac_key := derive_mac_key(passphrase, salt, params)
+	mac := compute_envelope_mac(mac_key, envelope_version, salt, params, []byte(ciphertext))
+
+	buf := bytes.NewBuffer(make([]byte, 0, envelope_header_len+len(ciphertext)))
+	buf.WriteString(envelope_magic)
+	buf.WriteByte(envelope_version)
+	buf.Write(salt)
+	binary.Write(buf, binary.BigEndian, params.Time)
+	binary.Write(buf, binary.BigEndian, params.MemoryKiB)
+	buf.WriteByte(params.Parallelism)
+	buf.Write(mac)
+	buf.WriteString(ciphertext)
+
+	return buf.Bytes(), nil
+}
+
+// open_envelope verifies an envelope's MAC and, if it matches, decrypts and
+// returns the plaintext. It refuses tampered or corrupt envelopes.
+func open_envelope(v Vigenere, envelope []byte, passphrase string) ([]byte, error) {
+	if len(envelope) < envelope_header_len {
+		return nil, errors.New("open_envelope: truncated envelope")
+	}
+	if string(envelope[:4]) != envelope_magic {
+		return nil, errors.New("open_envelope: not a go-vigenere envelope")
+	}
+
+	offset := 4
+	version := envelope[offset]
+	offset++
+	if version != envelope_version {
+		return nil, fmt.Errorf("open_envelope: unsupported envelope version %d", version)
+	}
+
+	salt := envelope[offset : offset+envelope_salt_size]
+	offset += envelope_salt_size
+
+	params := Argon2Params{
+		Time:      binary.BigEndian.Uint32(envelope[offset:]),
+		MemoryKiB: binary.BigEndian.Uint32(envelope[offset+4:]),
+	}
+	offset += 8
+	params.Parallelism = envelope[offset]
+	offset++
+
+	mac := envelope[offset : offset+envelope_mac_size]
+	offset += envelope_mac_size
+
+	ciphertext := envelope[offset:]
+
+	mac_key := derive_mac_key(passphrase, salt, params)
+	expected_mac := compute_envelope_mac(mac_key, version, salt, params, ciphertext)
+	if !hmac.Equal(mac, expected_mac) {
+		return nil, errors.New("open_envelope: authentication failed (wrong passphrase or the envelope was tampered with)")
+	}
+
+	key := derive_vigenere_key(passphrase, salt, params, len(ciphertext), v.table[0])
+	plaintext, err := v.decrypt(string(ciphertext), key)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(plaintext), nil
+}
+
+// run_envelope_command reads the whole of input_path, seals or opens it as
+// an --armored envelope depending on decrypting, and writes the result to
+// output_path. Unlike run_cipher_stream_command, it cannot stream: the
+// envelope's MAC has to be written before the ciphertext, and can only be
+// computed once the entire ciphertext is known.
+func run_envelope_command(vigenere Vigenere, decrypting bool, key string, input_path string, output_path string, params Argon2Params) {
+	input, _, close_input := open_input(input_path)
+	defer close_input()
+
+	data, err := io.ReadAll(input)
+	check(err)
+
+	output, _, close_output := open_output(output_path)
+	defer close_output()
+
+	if decrypting {
+		plaintext, err := open_envelope(vigenere, data, key)
+		check(err)
+		_, err = output.Write(plaintext)
+		check(err)
+	} else {
+		envelope, err := seal_envelope(vigenere, data, key, params)
+		check(err)
+		_, err = output.Write(envelope)
+		check(err)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func envelope_fixture(t *testing.T) Vigenere {
+	t.Helper()
+
+	var v Vigenere
+	if err := v.generate(ALPHABET); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	return v
+}
+
+// fast_argon2_params keeps the tests quick; production use relies on
+// default_argon2_params or paranoid_argon2_params instead.
+var fast_argon2_params = Argon2Params{Time: 1, MemoryKiB: 8 * 1024, Parallelism: 1}
+
+func TestSealOpenEnvelopeRoundTrip(t *testing.T) {
+	v := envelope_fixture(t)
+	plaintext := []byte("The quick brown fox jumps over the lazy dog.")
+
+	envelope, err := seal_envelope(v, plaintext, "correct horse battery staple", fast_argon2_params)
+	if err != nil {
+		t.Fatalf("seal_envelope: %v", err)
+	}
+
+	recovered, err := open_envelope(v, envelope, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("open_envelope: %v", err)
+	}
+	if string(recovered) != string(plaintext) {
+		t.Fatalf("recovered plaintext = %q, want %q", recovered, plaintext)
+	}
+}
+
+func TestOpenEnvelopeRejectsWrongPassphrase(t *testing.T) {
+	v := envelope_fixture(t)
+	plaintext := []byte("attack at dawn")
+
+	envelope, err := seal_envelope(v, plaintext, "correct horse battery staple", fast_argon2_params)
+	if err != nil {
+		t.Fatalf("seal_envelope: %v", err)
+	}
+
+	if _, err := open_envelope(v, envelope, "wrong passphrase"); err == nil {
+		t.Fatal("expected an error for a wrong passphrase")
+	}
+}
+
+func TestOpenEnvelopeRejectsTampering(t *testing.T) {
+	v := envelope_fixture(t)
+	plaintext := []byte("attack at dawn")
+
+	envelope, err := seal_envelope(v, plaintext, "correct horse battery staple", fast_argon2_params)
+	if err != nil {
+		t.Fatalf("seal_envelope: %v", err)
+	}
+
+	envelope[len(envelope)-1] ^= 0xFF
+
+	if _, err := open_envelope(v, envelope, "correct horse battery staple"); err == nil {
+		t.Fatal("expected an error for a tampered envelope")
+	}
+}
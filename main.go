@@ -1,23 +1,19 @@
 package main
 
 import (
-	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
 
 const YEL = "\033[0;33m"
 const WHT = "\033[0;37m"
-const DESCRIPTION = `Go-vigenere is a encryption and decryption tool based on the Vigenere cipher written in Go.
+const DESCRIPTION = `Go-vigenere is an encryption and decryption tool based on the Vigenere cipher
+and its classical relatives, written in Go.`
 
-Usage:
-	go <command> [arguments]
-
-The commands are:
-
-	encrypt    encipher text from a file with a specified key
-	decrypt    decipher text from a file with a specified key`
+// ALPHABET is the default set of valid plaintext/key characters.
+const ALPHABET = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz,. "
 
 func check(err error) {
 	if err != nil {
@@ -34,17 +30,12 @@ type Vigenere struct {
 // Generates a tabula recta for vigenere substitution.
 //   - `valid_chars` is the set of runes that makes up the plaintext and key/
 func (v *Vigenere) generate(valid_chars string) error {
-	v.table = make([]string, len(valid_chars))
-
-	for i := range valid_chars {
-		v.table[i] = str_rotate_left(valid_chars, i)
-	}
-
+	v.table = generate_table(valid_chars)
 	return nil
 }
 
 func (v Vigenere) available() bool {
-	return len(v.table) != 0
+	return table_available(v.table)
 }
 
 func str_rotate_left(str string, n int) string {
@@ -52,166 +43,50 @@ func str_rotate_left(str string, n int) string {
 }
 
 func (v Vigenere) substitute(char rune, keychar rune) (rune, error) {
-	if !v.available() {
-		return -1, errors.New("no vigenere table generated")
-	}
-
-	row := strings.IndexRune(v.table[0], char)
-	if row < 0 {
-		return -1, fmt.Errorf("substitute: character '%c' not found in table rows", char)
-	}
-
-	col := strings.IndexRune(v.table[0], keychar)
-	if col < 0 {
-		return -1, fmt.Errorf("substitute: character '%c' not found in table columns", keychar)
-	}
-
-	// Convert string to rune.
-	substituted := []rune(v.table[row])[col]
-
-	return substituted, nil
+	return table_substitute(v.table, char, keychar)
 }
 
 func (v Vigenere) reverse_substitute(char rune, keychar rune) (rune, error) {
-	if !v.available() {
-		return -1, errors.New("no vigenere table generated")
-	}
-
-	row := strings.IndexRune(v.table[0], keychar)
-	if row < 0 {
-		return -1, fmt.Errorf("substitute: character '%c' not found in table rows", keychar)
-	}
-
-	col := strings.IndexRune(v.table[row], char)
-	if col < 0 {
-		return -1, fmt.Errorf("substitute: character '%c' not found in table columns", char)
-	}
-
-	// Convert string to rune.
-	substituted := []rune(v.table[0])[col]
+	return table_reverse_substitute(v.table, char, keychar)
+}
 
-	return substituted, nil
+// reverse_substitute_shift recovers a plaintext rune from a ciphertext rune
+// given an integer key shift (the keychar's index in the alphabet) instead
+// of the keychar itself, so cryptanalysis code can work with shift indices.
+func (v Vigenere) reverse_substitute_shift(char rune, shift int) (rune, error) {
+	return table_reverse_substitute_shift(v.table, char, shift)
 }
 
+// encrypt enciphers plaintext against keystring (cycled as needed). It is a
+// thin, in-memory convenience wrapper around encrypt_stream for callers
+// (such as the cryptanalysis and envelope code) that already hold the whole
+// text in memory; the CLI itself streams through encrypt_stream directly.
 func (v Vigenere) encrypt(plaintext string, keystring string) (string, error) {
-	ciphertext := ""
-
-	if len(plaintext) > len(keystring) {
-		original := keystring
-		for len(keystring) < len(plaintext) {
-			keystring += original
-		}
-	}
-
-	for i := range plaintext {
-		tmp, err := v.substitute(rune(plaintext[i]), rune(keystring[i]))
-		check(err)
-
-		ciphertext += string(tmp)
-
-	}
-	return ciphertext, nil
+	var ciphertext strings.Builder
+	err := v.encrypt_stream(strings.NewReader(plaintext), &ciphertext, keystring, nil)
+	return ciphertext.String(), err
 }
 
+// decrypt deciphers ciphertext against keystring (cycled as needed). It is a
+// thin, in-memory convenience wrapper around decrypt_stream for callers
+// (such as the cryptanalysis and envelope code) that already hold the whole
+// text in memory; the CLI itself streams through decrypt_stream directly.
 func (v Vigenere) decrypt(ciphertext string, keystring string) (string, error) {
-	plaintext := ""
-
-	if len(ciphertext) > len(keystring) {
-		original := keystring
-		for len(keystring) < len(ciphertext) {
-			keystring += original
-		}
-	}
-
-	for i := range ciphertext {
-		tmp, err := v.reverse_substitute(rune(ciphertext[i]), rune(keystring[i]))
-		check(err)
+	var plaintext strings.Builder
+	err := v.decrypt_stream(strings.NewReader(ciphertext), &plaintext, keystring, nil)
+	return plaintext.String(), err
+}
 
-		plaintext += string(tmp)
+func (v Vigenere) encrypt_stream(r io.Reader, w io.Writer, keystring string, report func(int64)) error {
+	return generic_substitute_stream(r, w, keystring, report, v.substitute)
+}
 
-	}
-	return plaintext, nil
+func (v Vigenere) decrypt_stream(r io.Reader, w io.Writer, keystring string, report func(int64)) error {
+	return generic_substitute_stream(r, w, keystring, report, v.reverse_substitute)
 }
 
 func main() {
-	// Read command line arguments.
-	args := os.Args[1:]
-
-	if len(args) == 0 {
-		fmt.Println(DESCRIPTION)
-		fmt.Println()
-		os.Exit(0)
-	}
-
-	command := args[0]
-
-	if command == "help" {
-		args = args[1:]
-
-		if len(args) == 0 {
-			fmt.Println(DESCRIPTION)
-			fmt.Println()
-
-		} else if len(args) == 1 {
-			// topic := args[0]
-			panic("help topic not yet implemented")
-
-		} else {
-			fmt.Println("Usage:\n\n    go-vigenere.exe help <command>")
-			fmt.Println()
-			os.Exit(0)
-		}
-
-	} else if command == "encrypt" {
-		args = args[1:]
-		if len(args) != 2 {
-			fmt.Printf("Expected 2 arguments but got %v instead.\n\n", len(args))
-			fmt.Println("Run 'go-vigenere.exe help encrypt' to learn more.")
-			os.Exit(0)
-		}
-
-		key, plaintext_file := args[0], args[1] // TODO path strings
-
-		// Read plaintext from file.
-		data, err := os.ReadFile(plaintext_file)
-		check(err)
-		plaintext := string(data)
-
-		var vigenere Vigenere
-		err = vigenere.generate("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz,. ")
-		check(err)
-
-		ciphertext, err := vigenere.encrypt(plaintext, key)
-		check(err)
-
-		fmt.Println(ciphertext)
-
-	} else if command == "decrypt" {
-		args = args[1:]
-		if len(args) != 2 {
-			fmt.Printf("Expected 2 arguments but got %v instead.\n\n", len(args))
-			fmt.Println("Run 'go-vigenere.exe help decrypt' to learn more.")
-			os.Exit(0)
-		}
-
-		key, ciphertext_file := args[0], args[1] // TODO path strings
-
-		// Read plaintext from file.
-		data, err := os.ReadFile(ciphertext_file)
-		check(err)
-		ciphertext := string(data)
-
-		var vigenere Vigenere
-		err = vigenere.generate("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz,. ")
-		check(err)
-
-		plaintext, err := vigenere.decrypt(ciphertext, key)
-		check(err)
-
-		fmt.Println(plaintext)
-
-	} else {
-		fmt.Printf("Unknown command provided: %v\n", command)
-		fmt.Println("Run 'go-vigenere.exe help' for usage.")
+	if err := new_root_command().Execute(); err != nil {
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// mnemonic_word_counts are the phrase lengths keygen and --mnemonic accept,
+// mirroring BIP39's 12/18/24-word conventions. The final word of every
+// phrase is a checksum word, not key material.
+var mnemonic_word_counts = map[int]bool{12: true, 18: true, 24: true}
+
+// mnemonic_key_length is the length of the Vigenere key expanded from a
+// mnemonic's derived entropy; like any other key it is then cycled across
+// the stream, so it only needs to be long enough to avoid a short repeat.
+const mnemonic_key_length = 256
+
+var mnemonic_word_index = build_mnemonic_word_index()
+
+func build_mnemonic_word_index() map[string]int {
+	index := make(map[string]int, len(mnemonic_wordlist))
+	for i, word := range mnemonic_wordlist {
+		index[word] = i
+	}
+	return index
+}
+
+// generate_mnemonic picks word_count-1 random words from mnemonic_wordlist
+// and appends a checksum word derived from them, so keygen's output can
+// later be validated by parse_and_verify_mnemonic before it's trusted as a
+// key.
+func generate_mnemonic(word_count int) (string, error) {
+	if !mnemonic_word_counts[word_count] {
+		return "", fmt.Errorf("keygen: word count must be 12, 18, or 24, got %d", word_count)
+	}
+
+	words := make([]string, word_count-1)
+	for i := range words {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(mnemonic_wordlist))))
+		if err != nil {
+			return "", fmt.Errorf("keygen: %w", err)
+		}
+		words[i] = mnemonic_wordlist[n.Int64()]
+	}
+
+	return strings.Join(append(words, mnemonic_checksum_word(words)), " "), nil
+}
+
+// mnemonic_checksum_word derives the checksum word appended to data_words:
+// the first 11 bits of BLAKE2b-256(data_words) index into mnemonic_wordlist.
+func mnemonic_checksum_word(data_words []string) string {
+	sum := blake2b.Sum256([]byte(strings.Join(data_words, " ")))
+	idx := (int(sum[0])<<8 | int(sum[1])) % len(mnemonic_wordlist)
+	return mnemonic_wordlist[idx]
+}
+
+// parse_and_verify_mnemonic splits phrase into words, checks its length and
+// that every word is in mnemonic_wordlist, and verifies the trailing
+// checksum word so a single mistyped or reordered word is caught here
+// rather than silently producing garbage key material.
+func parse_and_verify_mnemonic(phrase string) ([]string, error) {
+	words := strings.Fields(phrase)
+	if !mnemonic_word_counts[len(words)] {
+		return nil, fmt.Errorf("mnemonic: expected 12, 18, or 24 words, got %d", len(words))
+	}
+
+	for _, word := range words {
+		if _, ok := mnemonic_word_index[word]; !ok {
+			return nil, fmt.Errorf("mnemonic: %q is not in the word list", word)
+		}
+	}
+
+	data_words, checksum_word := words[:len(words)-1], words[len(words)-1]
+	if want := mnemonic_checksum_word(data_words); checksum_word != want {
+		return nil, errors.New("mnemonic: checksum word mismatch, check for a mistyped or reordered word")
+	}
+
+	return words, nil
+}
+
+// derive_key_from_mnemonic verifies phrase and expands its BLAKE2b-256 hash
+// into an alphabet-valid Vigenere key via rejection sampling, the same
+// technique derive_vigenere_key (envelope.go) uses for passphrases.
+func derive_key_from_mnemonic(phrase string, length int, alphabet string) (string, error) {
+	words, err := parse_and_verify_mnemonic(phrase)
+	if err != nil {
+		return "", err
+	}
+
+	entropy := blake2b.Sum256([]byte(strings.Join(words, " ")))
+	return mnemonic_expand_key(entropy[:], length, alphabet), nil
+}
+
+// mnemonic_expand_key stretches seed into a length-rune key drawn from
+// alphabet, hashing seed with an incrementing block counter and
+// rejection-sampling each output byte to avoid modulo bias.
+func mnemonic_expand_key(seed []byte, length int, alphabet string) string {
+	alphabet_len := len(alphabet)
+	reject_above := byte(256 - (256 % alphabet_len))
+
+	key := make([]byte, 0, length)
+	for block := uint32(0); len(key) < length; block++ {
+		h, _ := blake2b.New256(nil)
+		h.Write(seed)
+		var counter [4]byte
+		binary.BigEndian.PutUint32(counter[:], block)
+		h.Write(counter[:])
+
+		for _, b := range h.Sum(nil) {
+			if b >= reject_above {
+				continue
+			}
+			key = append(key, alphabet[int(b)%alphabet_len])
+			if len(key) == length {
+				break
+			}
+		}
+	}
+
+	return string(key)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateMnemonicRejectsBadWordCount(t *testing.T) {
+	if _, err := generate_mnemonic(13); err == nil {
+		t.Fatal("expected an error for an unsupported word count")
+	}
+}
+
+func TestGenerateMnemonicRoundTripsThroughVerify(t *testing.T) {
+	for _, word_count := range []int{12, 18, 24} {
+		phrase, err := generate_mnemonic(word_count)
+		if err != nil {
+			t.Fatalf("generate_mnemonic(%d): %v", word_count, err)
+		}
+
+		words := strings.Fields(phrase)
+		if len(words) != word_count {
+			t.Fatalf("generate_mnemonic(%d) produced %d words", word_count, len(words))
+		}
+
+		if _, err := parse_and_verify_mnemonic(phrase); err != nil {
+			t.Fatalf("parse_and_verify_mnemonic rejected its own output: %v", err)
+		}
+	}
+}
+
+func TestParseAndVerifyMnemonicRejectsTamperedWord(t *testing.T) {
+	phrase, err := generate_mnemonic(12)
+	if err != nil {
+		t.Fatalf("generate_mnemonic: %v", err)
+	}
+
+	words := strings.Fields(phrase)
+	original := words[0]
+	replacement := mnemonic_wordlist[0]
+	if replacement == original {
+		replacement = mnemonic_wordlist[1]
+	}
+	words[0] = replacement
+
+	if _, err := parse_and_verify_mnemonic(strings.Join(words, " ")); err == nil {
+		t.Fatal("expected a checksum mismatch after tampering with a data word")
+	}
+}
+
+func TestParseAndVerifyMnemonicRejectsUnknownWord(t *testing.T) {
+	phrase := strings.Repeat("notarealword ", 11) + "notarealword"
+	if _, err := parse_and_verify_mnemonic(phrase); err == nil {
+		t.Fatal("expected an error for words outside the word list")
+	}
+}
+
+func TestDeriveKeyFromMnemonicIsDeterministic(t *testing.T) {
+	phrase, err := generate_mnemonic(12)
+	if err != nil {
+		t.Fatalf("generate_mnemonic: %v", err)
+	}
+
+	key1, err := derive_key_from_mnemonic(phrase, 64, ALPHABET)
+	if err != nil {
+		t.Fatalf("derive_key_from_mnemonic: %v", err)
+	}
+	key2, err := derive_key_from_mnemonic(phrase, 64, ALPHABET)
+	if err != nil {
+		t.Fatalf("derive_key_from_mnemonic: %v", err)
+	}
+
+	if key1 != key2 {
+		t.Fatal("derive_key_from_mnemonic produced different keys for the same phrase")
+	}
+	if len(key1) != 64 {
+		t.Fatalf("derive_key_from_mnemonic returned %d runes, want 64", len(key1))
+	}
+	for _, r := range key1 {
+		if !strings.ContainsRune(ALPHABET, r) {
+			t.Fatalf("derived key contains rune %q outside ALPHABET", r)
+		}
+	}
+}
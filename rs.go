@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/vivint/infectious"
+)
+
+// Default Reed-Solomon shape: each 128-byte plaintext block becomes 136
+// one-byte shares (128 data + 8 parity), correcting up to 4 corrupted bytes
+// per block. -r/--reed-solomon, --rs-required, --rs-total, and
+// --rs-block-size on `encrypt` override these; the chosen shape is recorded
+// in the stream header so `decrypt` never needs matching flags.
+const (
+	rs_default_block_size = 128
+	rs_default_required   = 128
+	rs_default_total      = 136
+)
+
+// rs_magic marks a ciphertext stream as Reed-Solomon encoded, so decrypt can
+// detect and strip the layer transparently.
+const rs_magic = "RSV1"
+
+// ReedSolomonParams describes the shape of the RS layer encrypt interposes
+// between Vigenere substitution and file I/O.
+type ReedSolomonParams struct {
+	BlockSize int // plaintext bytes protected per block
+	Required  int // data shares per block (== BlockSize for the one-byte-per-share default)
+	Total     int // shares emitted per block; Total-Required is the parity budget
+}
+
+var default_reed_solomon_params = ReedSolomonParams{
+	BlockSize: rs_default_block_size,
+	Required:  rs_default_required,
+	Total:     rs_default_total,
+}
+
+// RSDecodeOptions controls how decrypt handles an RS-encoded stream.
+type RSDecodeOptions struct {
+	Fix  bool // attempt Berlekamp-Welch correction and report repaired/unrecoverable block counts
+	Keep bool // emit a best-effort block instead of failing when a block is unrecoverable
+}
+
+// RSDecodeStats tallies what rs_decoder did across a stream, for --fix's
+// stderr report.
+type RSDecodeStats struct {
+	Blocks        int
+	Repaired      int
+	Unrecoverable int
+}
+
+func write_rs_header(w io.Writer, params ReedSolomonParams) error {
+	if _, err := io.WriteString(w, rs_magic); err != nil {
+		return err
+	}
+	for _, v := range []int{params.BlockSize, params.Required, params.Total} {
+		if err := binary.Write(w, binary.BigEndian, uint32(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func read_rs_header(r io.Reader) (ReedSolomonParams, error) {
+	var block_size, required, total uint32
+	for _, dst := range []*uint32{&block_size, &required, &total} {
+		if err := binary.Read(r, binary.BigEndian, dst); err != nil {
+			return ReedSolomonParams{}, err
+		}
+	}
+	return ReedSolomonParams{BlockSize: int(block_size), Required: int(required), Total: int(total)}, nil
+}
+
+// pad_block returns data truncated or zero-padded to exactly size bytes.
+func pad_block(data []byte, size int) []byte {
+	if len(data) >= size {
+		return data[:size]
+	}
+	padded := make([]byte, size)
+	copy(padded, data)
+	return padded
+}
+
+// rs_encoder wraps an io.Writer, buffering writes into params.BlockSize
+// chunks and writing each as a length-prefixed, Reed-Solomon-encoded block.
+// It writes the rs_magic header on the first block, so a plain decrypt of
+// an RS-encoded stream fails loudly instead of silently misreading it.
+type rs_encoder struct {
+	w              io.Writer
+	fec            *infectious.FEC
+	params         ReedSolomonParams
+	buf            []byte
+	header_written bool
+}
+
+func new_rs_encoder(w io.Writer, params ReedSolomonParams) (*rs_encoder, error) {
+	fec, err := infectious.NewFEC(params.Required, params.Total)
+	if err != nil {
+		return nil, fmt.Errorf("reed-solomon: %w", err)
+	}
+	return &rs_encoder{w: w, fec: fec, params: params}, nil
+}
+
+func (e *rs_encoder) Write(p []byte) (int, error) {
+	if err := e.ensure_header(); err != nil {
+		return 0, err
+	}
+
+	e.buf = append(e.buf, p...)
+	for len(e.buf) >= e.params.BlockSize {
+		if err := e.flush_block(e.buf[:e.params.BlockSize]); err != nil {
+			return 0, err
+		}
+		e.buf = e.buf[e.params.BlockSize:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes out any buffered remainder as a final (short) block. It must
+// be called once the underlying cipher stream has finished writing.
+func (e *rs_encoder) Flush() error {
+	if err := e.ensure_header(); err != nil {
+		return err
+	}
+	if len(e.buf) == 0 {
+		return nil
+	}
+
+	err := e.flush_block(e.buf)
+	e.buf = nil
+	return err
+}
+
+func (e *rs_encoder) ensure_header() error {
+	if e.header_written {
+		return nil
+	}
+	if err := write_rs_header(e.w, e.params); err != nil {
+		return err
+	}
+	e.header_written = true
+	return nil
+}
+
+func (e *rs_encoder) flush_block(data []byte) error {
+	n := len(data)
+	block := pad_block(data, e.params.BlockSize)
+
+	if err := binary.Write(e.w, binary.BigEndian, uint32(n)); err != nil {
+		return err
+	}
+
+	shares := make([]byte, e.params.Total)
+	err := e.fec.Encode(block, func(s infectious.Share) { shares[s.Number] = s.Data[0] })
+	if err != nil {
+		return fmt.Errorf("reed-solomon: encode: %w", err)
+	}
+
+	_, err = e.w.Write(shares)
+	return err
+}
+
+// rs_decoder wraps an io.Reader positioned just past the rs_magic header,
+// decoding length-prefixed RS blocks into a byte stream the cipher's
+// decrypt_stream can read from exactly as if the RS layer weren't there.
+type rs_decoder struct {
+	r       io.Reader
+	fec     *infectious.FEC
+	params  ReedSolomonParams
+	opts    RSDecodeOptions
+	pending []byte
+	stats   RSDecodeStats
+	err     error
+}
+
+func new_rs_decoder(r io.Reader, params ReedSolomonParams, opts RSDecodeOptions) (*rs_decoder, error) {
+	fec, err := infectious.NewFEC(params.Required, params.Total)
+	if err != nil {
+		return nil, fmt.Errorf("reed-solomon: %w", err)
+	}
+	return &rs_decoder{r: r, fec: fec, params: params, opts: opts}, nil
+}
+
+func (d *rs_decoder) Read(p []byte) (int, error) {
+	for len(d.pending) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+
+		block, err := d.next_block()
+		if err != nil {
+			d.err = err
+			if d.err == io.ErrUnexpectedEOF {
+				d.err = io.EOF
+			}
+			return 0, d.err
+		}
+		d.pending = block
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+func (d *rs_decoder) next_block() ([]byte, error) {
+	var n uint32
+	if err := binary.Read(d.r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+
+	shares := make([]byte, d.params.Total)
+	if _, err := io.ReadFull(d.r, shares); err != nil {
+		return nil, fmt.Errorf("reed-solomon: truncated block: %w", err)
+	}
+	d.stats.Blocks++
+
+	if !d.opts.Fix {
+		// Fast path: the code is systematic, so the first Required shares
+		// are the original data verbatim. No correction is attempted.
+		decoded := append([]byte{}, shares[:d.params.Required]...)
+		return trim_block(decoded, n), nil
+	}
+
+	share_list := make([]infectious.Share, d.params.Total)
+	for i, b := range shares {
+		share_list[i] = infectious.Share{Number: i, Data: []byte{b}}
+	}
+
+	decoded, decode_err := d.fec.Decode(nil, share_list)
+	if decode_err != nil {
+		d.stats.Unrecoverable++
+		if !d.opts.Keep {
+			return nil, fmt.Errorf("reed-solomon: block %d unrecoverable: %w", d.stats.Blocks, decode_err)
+		}
+		decoded = append([]byte{}, shares[:d.params.Required]...)
+	} else if repaired := count_repaired(d.fec, decoded, shares, d.params); repaired > 0 {
+		d.stats.Repaired++
+	}
+
+	return trim_block(decoded, n), nil
+}
+
+func trim_block(decoded []byte, n uint32) []byte {
+	if int(n) < len(decoded) {
+		return decoded[:n]
+	}
+	return decoded
+}
+
+// count_repaired re-encodes decoded and compares the result against the
+// shares as actually read, to report how many shares of this block differed
+// from what a clean encoding would have produced.
+func count_repaired(fec *infectious.FEC, decoded []byte, shares []byte, params ReedSolomonParams) int {
+	block := pad_block(decoded, params.BlockSize)
+
+	clean := make([]byte, params.Total)
+	if err := fec.Encode(block, func(s infectious.Share) { clean[s.Number] = s.Data[0] }); err != nil {
+		return 0
+	}
+
+	mismatches := 0
+	for i := range clean {
+		if clean[i] != shares[i] {
+			mismatches++
+		}
+	}
+	return mismatches
+}
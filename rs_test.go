@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func small_rs_params() ReedSolomonParams {
+	return ReedSolomonParams{BlockSize: 16, Required: 16, Total: 20}
+}
+
+func TestRSEncodeDecodeRoundTrip(t *testing.T) {
+	plaintext := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20)
+
+	var encoded bytes.Buffer
+	encoder, err := new_rs_encoder(&encoded, small_rs_params())
+	if err != nil {
+		t.Fatalf("new_rs_encoder: %v", err)
+	}
+	if _, err := encoder.Write([]byte(plaintext)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := encoder.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	reader, decoder, err := wrap_rs_decode_reader(&encoded, RSDecodeOptions{})
+	if err != nil {
+		t.Fatalf("wrap_rs_decode_reader: %v", err)
+	}
+	if decoder == nil {
+		t.Fatal("expected the RS header to be detected")
+	}
+
+	var recovered bytes.Buffer
+	if _, err := recovered.ReadFrom(reader); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+
+	if recovered.String() != plaintext {
+		t.Fatalf("recovered = %q, want %q", recovered.String(), plaintext)
+	}
+}
+
+func TestRSDecodeRepairsCorruptedShare(t *testing.T) {
+	plaintext := "MEET AT THE OLD BRIDGE AT MIDNIGHT, BRING THE MAP AND THE KEY"
+
+	var encoded bytes.Buffer
+	params := small_rs_params()
+	encoder, err := new_rs_encoder(&encoded, params)
+	if err != nil {
+		t.Fatalf("new_rs_encoder: %v", err)
+	}
+	if _, err := encoder.Write([]byte(plaintext)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := encoder.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	corrupted := encoded.Bytes()
+	// Flip one byte inside the first block's shares, well past the header
+	// and length prefix.
+	flip_at := len(rs_magic) + 4*3 + 4 + 2
+	corrupted[flip_at] ^= 0xFF
+
+	reader, decoder, err := wrap_rs_decode_reader(bytes.NewReader(corrupted), RSDecodeOptions{Fix: true})
+	if err != nil {
+		t.Fatalf("wrap_rs_decode_reader: %v", err)
+	}
+
+	var recovered bytes.Buffer
+	if _, err := recovered.ReadFrom(reader); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+
+	if recovered.String() != plaintext {
+		t.Fatalf("recovered = %q, want %q", recovered.String(), plaintext)
+	}
+	if decoder.stats.Repaired == 0 {
+		t.Error("expected at least one repaired block to be reported")
+	}
+}
+
+func TestRSDecodePassesThroughWithoutHeader(t *testing.T) {
+	plain := []byte("no reed-solomon layer here")
+
+	reader, decoder, err := wrap_rs_decode_reader(bytes.NewReader(plain), RSDecodeOptions{})
+	if err != nil {
+		t.Fatalf("wrap_rs_decode_reader: %v", err)
+	}
+	if decoder != nil {
+		t.Fatal("expected no RS decoder when the header is absent")
+	}
+
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(reader); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if out.String() != string(plain) {
+		t.Fatalf("passthrough = %q, want %q", out.String(), string(plain))
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// stream_chunk_size is the buffer size used when streaming plaintext or
+// ciphertext through the tabula recta, chosen to keep memory usage flat
+// regardless of input size.
+const stream_chunk_size = 64 * 1024
+
+// open_input resolves an -i argument ("-" or "" means stdin) to a reader, the
+// input's size in bytes if known (0 if it can't be determined, e.g. stdin),
+// and a close function to defer.
+func open_input(path string) (io.Reader, int64, func()) {
+	if path == "" || path == "-" {
+		return os.Stdin, 0, func() {}
+	}
+
+	f, err := os.Open(path)
+	check(err)
+
+	size := int64(0)
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	return f, size, func() { f.Close() }
+}
+
+// open_output resolves an -o argument ("-" or "" means stdout) to a writer,
+// the underlying *os.File (used to detect whether it's a terminal), and a
+// close function to defer.
+func open_output(path string) (io.Writer, *os.File, func()) {
+	if path == "" || path == "-" {
+		return os.Stdout, os.Stdout, func() {}
+	}
+
+	f, err := os.Create(path)
+	check(err)
+
+	return f, f, func() { f.Close() }
+}
+
+// is_terminal reports whether f is attached to a terminal.
+func is_terminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// progress_reporter returns a report function that renders an age/progressbar
+// -style progress line (bytes processed, throughput, ETA) to w after every
+// call, given the total number of bytes expected.
+func progress_reporter(w io.Writer, total int64) func(int64) {
+	start := time.Now()
+
+	return func(done int64) {
+		elapsed := time.Since(start).Seconds()
+		if elapsed <= 0 {
+			elapsed = 0.001
+		}
+
+		throughput := float64(done) / elapsed
+		percent := float64(done) / float64(total) * 100
+		if percent > 100 {
+			percent = 100
+		}
+
+		eta := "?"
+		if throughput > 0 && done < total {
+			remaining := float64(total-done) / throughput
+			eta = time.Duration(remaining * float64(time.Second)).Round(time.Second).String()
+		} else if done >= total {
+			eta = "0s"
+		}
+
+		fmt.Fprintf(w, "\r%6.2f%%  %d/%d bytes  %s/s  ETA %s", percent, done, total, human_bytes(throughput), eta)
+	}
+}
+
+// human_bytes formats a byte count (or byte rate) with a binary unit suffix.
+func human_bytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+
+	return fmt.Sprintf("%.1f%s", n, units[i])
+}
+
+// rs_magic_len bytes are peeked from the start of a decrypting stream to
+// detect an RS-encoded ciphertext; see wrap_rs_decode_reader.
+var rs_magic_len = len(rs_magic)
+
+// wrap_rs_decode_reader peeks at input for the rs_magic header. If present,
+// it consumes the header and returns an rs_decoder (which also streams the
+// decoded bytes) so decrypt transparently repairs or strips the RS layer;
+// otherwise it returns input unchanged (wrapped in a bufio.Reader, since
+// Peek already consumed from it).
+func wrap_rs_decode_reader(input io.Reader, opts RSDecodeOptions) (io.Reader, *rs_decoder, error) {
+	br := bufio.NewReaderSize(input, rs_magic_len)
+
+	magic, err := br.Peek(rs_magic_len)
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+	if string(magic) != rs_magic {
+		return br, nil, nil
+	}
+
+	if _, err := br.Discard(rs_magic_len); err != nil {
+		return nil, nil, err
+	}
+
+	params, err := read_rs_header(br)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reed-solomon: %w", err)
+	}
+
+	decoder, err := new_rs_decoder(br, params, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return decoder, decoder, nil
+}
+
+// run_cipher_stream_command streams plaintext/ciphertext from input_path to
+// output_path through cipher, cycling key across the whole stream (except
+// for variants, like RunningKey, that manage their own keystream), and
+// prints a progress bar to stderr when writing to a non-terminal output of
+// known size. When encrypting, rs_params (if non-nil) wraps the ciphertext
+// in a Reed-Solomon layer; when decrypting, rs_opts controls whether an
+// RS layer found in the input is repaired (and how failures are handled).
+func run_cipher_stream_command(cipher Cipher, key string, input_path string, output_path string, decrypting bool, rs_params *ReedSolomonParams, rs_opts RSDecodeOptions) {
+	input, size, close_input := open_input(input_path)
+	defer close_input()
+
+	output, output_file, close_output := open_output(output_path)
+	defer close_output()
+
+	// RunningKey's key names a second file holding the running key text.
+	if _, ok := cipher.(*RunningKey); ok {
+		data, err := os.ReadFile(key)
+		check(err)
+		key = string(data)
+	}
+
+	err := cipher.generate(ALPHABET)
+	check(err)
+
+	var report func(int64)
+	if size > 0 && !is_terminal(output_file) {
+		report = progress_reporter(os.Stderr, size)
+	}
+
+	if decrypting {
+		reader, decoder, err := wrap_rs_decode_reader(input, rs_opts)
+		check(err)
+
+		err = cipher.decrypt_stream(reader, output, key, report)
+		check(err)
+
+		if report != nil {
+			fmt.Fprintln(os.Stderr)
+		}
+
+		if decoder != nil && rs_opts.Fix {
+			fmt.Fprintf(os.Stderr, "reed-solomon: %d block(s), %d repaired, %d unrecoverable\n",
+				decoder.stats.Blocks, decoder.stats.Repaired, decoder.stats.Unrecoverable)
+		}
+		return
+	}
+
+	var writer io.Writer = output
+	var encoder *rs_encoder
+	if rs_params != nil {
+		encoder, err = new_rs_encoder(output, *rs_params)
+		check(err)
+		writer = encoder
+	}
+
+	err = cipher.encrypt_stream(input, writer, key, report)
+	check(err)
+
+	if encoder != nil {
+		check(encoder.Flush())
+	}
+
+	if report != nil {
+		fmt.Fprintln(os.Stderr)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecryptStreamRoundTrip(t *testing.T) {
+	var v Vigenere
+	if err := v.generate(ALPHABET); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	plaintext := strings.Repeat("The Quick Brown Fox Jumps Over The Lazy Dog. ", 500)
+	key := "STREAMING"
+
+	var ciphertext strings.Builder
+	if err := v.encrypt_stream(strings.NewReader(plaintext), &ciphertext, key, nil); err != nil {
+		t.Fatalf("encrypt_stream: %v", err)
+	}
+
+	var recovered strings.Builder
+	if err := v.decrypt_stream(strings.NewReader(ciphertext.String()), &recovered, key, nil); err != nil {
+		t.Fatalf("decrypt_stream: %v", err)
+	}
+
+	if recovered.String() != plaintext {
+		t.Fatalf("round trip mismatch: got %d bytes, want %d bytes", recovered.Len(), len(plaintext))
+	}
+}
+
+func TestEncryptStreamReportsProgress(t *testing.T) {
+	var v Vigenere
+	if err := v.generate(ALPHABET); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	plaintext := strings.Repeat("A", stream_chunk_size+10)
+
+	var calls []int64
+	var ciphertext strings.Builder
+	err := v.encrypt_stream(strings.NewReader(plaintext), &ciphertext, "KEY", func(n int64) {
+		calls = append(calls, n)
+	})
+	if err != nil {
+		t.Fatalf("encrypt_stream: %v", err)
+	}
+
+	if len(calls) < 2 {
+		t.Fatalf("expected progress to be reported across multiple chunks, got %d calls", len(calls))
+	}
+	if calls[len(calls)-1] != int64(len(plaintext)) {
+		t.Errorf("final progress = %d, want %d", calls[len(calls)-1], len(plaintext))
+	}
+}
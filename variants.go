@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Beaufort is the Beaufort cipher: substitution is (key - plaintext) mod N,
+// which makes it its own inverse, so encrypt_stream and decrypt_stream share
+// the same lookup (table_beaufort).
+type Beaufort struct {
+	table []string
+}
+
+func (c *Beaufort) generate(valid_chars string) error {
+	c.table = generate_table(valid_chars)
+	return nil
+}
+
+func (c Beaufort) substitute(char rune, keychar rune) (rune, error) {
+	return table_beaufort(c.table, char, keychar)
+}
+
+func (c Beaufort) encrypt_stream(r io.Reader, w io.Writer, key string, report func(int64)) error {
+	return generic_substitute_stream(r, w, key, report, c.substitute)
+}
+
+func (c Beaufort) decrypt_stream(r io.Reader, w io.Writer, key string, report func(int64)) error {
+	return generic_substitute_stream(r, w, key, report, c.substitute)
+}
+
+// Gronsfeld is the Gronsfeld cipher: a Vigenere variant keyed by numeric
+// digits 0-9 instead of alphabet characters, so each key digit is used
+// directly as a shift rather than looked up by rune identity.
+type Gronsfeld struct {
+	table []string
+}
+
+func (c *Gronsfeld) generate(valid_chars string) error {
+	c.table = generate_table(valid_chars)
+	return nil
+}
+
+// gronsfeld_shift resolves a key digit rune ('0'-'9') to its shift amount.
+func gronsfeld_shift(digit rune) (int, error) {
+	if digit < '0' || digit > '9' {
+		return 0, fmt.Errorf("gronsfeld: key digit '%c' is not a decimal digit 0-9", digit)
+	}
+	return int(digit - '0'), nil
+}
+
+func (c Gronsfeld) substitute(char rune, digit rune) (rune, error) {
+	shift, err := gronsfeld_shift(digit)
+	if err != nil {
+		return -1, err
+	}
+	return table_substitute_shift(c.table, char, shift)
+}
+
+func (c Gronsfeld) reverse_substitute(char rune, digit rune) (rune, error) {
+	shift, err := gronsfeld_shift(digit)
+	if err != nil {
+		return -1, err
+	}
+	return table_reverse_substitute_shift(c.table, char, shift)
+}
+
+func (c Gronsfeld) encrypt_stream(r io.Reader, w io.Writer, key string, report func(int64)) error {
+	return generic_substitute_stream(r, w, key, report, c.substitute)
+}
+
+func (c Gronsfeld) decrypt_stream(r io.Reader, w io.Writer, key string, report func(int64)) error {
+	return generic_substitute_stream(r, w, key, report, c.reverse_substitute)
+}
+
+// Autokey is the autokey cipher: the keystream starts with the supplied key
+// and is then extended with the plaintext itself, rather than repeating the
+// key. Decrypting feeds the recovered plaintext back into the keystream as
+// it goes, so it needs its own stream loop instead of generic_substitute_stream.
+type Autokey struct {
+	table []string
+}
+
+func (c *Autokey) generate(valid_chars string) error {
+	c.table = generate_table(valid_chars)
+	return nil
+}
+
+func (c Autokey) encrypt_stream(r io.Reader, w io.Writer, key string, report func(int64)) error {
+	if len(key) == 0 {
+		return errors.New("autokey: empty key")
+	}
+
+	buf := make([]byte, stream_chunk_size)
+	out := make([]byte, stream_chunk_size)
+	keystream := []byte(key)
+	pos := 0
+	var written int64
+
+	for {
+		n, read_err := r.Read(buf)
+		if n > 0 {
+			for i := 0; i < n; i++ {
+				tmp, err := table_substitute(c.table, rune(buf[i]), rune(keystream[pos]))
+				if err != nil {
+					return err
+				}
+				out[i] = byte(tmp)
+				keystream = append(keystream, buf[i])
+				pos++
+			}
+
+			if _, err := w.Write(out[:n]); err != nil {
+				return err
+			}
+
+			written += int64(n)
+			if report != nil {
+				report(written)
+			}
+		}
+
+		if read_err == io.EOF {
+			return nil
+		}
+		if read_err != nil {
+			return read_err
+		}
+	}
+}
+
+func (c Autokey) decrypt_stream(r io.Reader, w io.Writer, key string, report func(int64)) error {
+	if len(key) == 0 {
+		return errors.New("autokey: empty key")
+	}
+
+	buf := make([]byte, stream_chunk_size)
+	out := make([]byte, stream_chunk_size)
+	keystream := []byte(key)
+	pos := 0
+	var written int64
+
+	for {
+		n, read_err := r.Read(buf)
+		if n > 0 {
+			for i := 0; i < n; i++ {
+				tmp, err := table_reverse_substitute(c.table, rune(buf[i]), rune(keystream[pos]))
+				if err != nil {
+					return err
+				}
+				out[i] = byte(tmp)
+				keystream = append(keystream, byte(tmp))
+				pos++
+			}
+
+			if _, err := w.Write(out[:n]); err != nil {
+				return err
+			}
+
+			written += int64(n)
+			if report != nil {
+				report(written)
+			}
+		}
+
+		if read_err == io.EOF {
+			return nil
+		}
+		if read_err != nil {
+			return read_err
+		}
+	}
+}
+
+// RunningKey is the running-key cipher: the key is an independent text at
+// least as long as the plaintext (conventionally read from a second file)
+// and is never cycled; running out of key characters is an error rather
+// than wrapping back to the start.
+type RunningKey struct {
+	table []string
+}
+
+func (c *RunningKey) generate(valid_chars string) error {
+	c.table = generate_table(valid_chars)
+	return nil
+}
+
+func (c RunningKey) encrypt_stream(r io.Reader, w io.Writer, key string, report func(int64)) error {
+	return c.stream(r, w, key, report, table_substitute)
+}
+
+func (c RunningKey) decrypt_stream(r io.Reader, w io.Writer, key string, report func(int64)) error {
+	return c.stream(r, w, key, report, table_reverse_substitute)
+}
+
+func (c RunningKey) stream(r io.Reader, w io.Writer, key string, report func(int64), lookup func(table []string, char rune, keychar rune) (rune, error)) error {
+	if len(key) == 0 {
+		return errors.New("running-key: empty key")
+	}
+
+	buf := make([]byte, stream_chunk_size)
+	out := make([]byte, stream_chunk_size)
+	pos := 0
+	var written int64
+
+	for {
+		n, read_err := r.Read(buf)
+		if n > 0 {
+			for i := 0; i < n; i++ {
+				if pos >= len(key) {
+					return fmt.Errorf("running-key: key exhausted after %d characters, but the input is longer", len(key))
+				}
+
+				tmp, err := lookup(c.table, rune(buf[i]), rune(key[pos]))
+				if err != nil {
+					return err
+				}
+				out[i] = byte(tmp)
+				pos++
+			}
+
+			if _, err := w.Write(out[:n]); err != nil {
+				return err
+			}
+
+			written += int64(n)
+			if report != nil {
+				report(written)
+			}
+		}
+
+		if read_err == io.EOF {
+			return nil
+		}
+		if read_err != nil {
+			return read_err
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// round_trip_cipher encrypts plaintext under key with cipher, then decrypts
+// the result and returns both the ciphertext and recovered plaintext.
+func round_trip_cipher(t *testing.T, cipher Cipher, plaintext string, key string) (string, string) {
+	t.Helper()
+
+	if err := cipher.generate(ALPHABET); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	var ciphertext strings.Builder
+	if err := cipher.encrypt_stream(strings.NewReader(plaintext), &ciphertext, key, nil); err != nil {
+		t.Fatalf("encrypt_stream: %v", err)
+	}
+
+	var recovered strings.Builder
+	if err := cipher.decrypt_stream(strings.NewReader(ciphertext.String()), &recovered, key, nil); err != nil {
+		t.Fatalf("decrypt_stream: %v", err)
+	}
+
+	return ciphertext.String(), recovered.String()
+}
+
+func TestBeaufortRoundTrip(t *testing.T) {
+	plaintext := "MEET AT THE OLD BRIDGE AT MIDNIGHT"
+	_, recovered := round_trip_cipher(t, &Beaufort{}, plaintext, "FORTIFICATION")
+
+	if recovered != plaintext {
+		t.Errorf("recovered = %q, want %q", recovered, plaintext)
+	}
+}
+
+func TestGronsfeldRoundTrip(t *testing.T) {
+	plaintext := "MEET AT THE OLD BRIDGE AT MIDNIGHT"
+	_, recovered := round_trip_cipher(t, &Gronsfeld{}, plaintext, "31415926")
+
+	if recovered != plaintext {
+		t.Errorf("recovered = %q, want %q", recovered, plaintext)
+	}
+}
+
+func TestGronsfeldRejectsNonDigitKey(t *testing.T) {
+	var cipher Gronsfeld
+	if err := cipher.generate(ALPHABET); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	var out strings.Builder
+	err := cipher.encrypt_stream(strings.NewReader("HELLO"), &out, "ABCDE", nil)
+	if err == nil {
+		t.Fatal("expected an error for a non-digit Gronsfeld key")
+	}
+}
+
+func TestAutokeyRoundTrip(t *testing.T) {
+	plaintext := "MEET AT THE OLD BRIDGE AT MIDNIGHT"
+	_, recovered := round_trip_cipher(t, &Autokey{}, plaintext, "QUEEN")
+
+	if recovered != plaintext {
+		t.Errorf("recovered = %q, want %q", recovered, plaintext)
+	}
+}
+
+func TestRunningKeyRoundTrip(t *testing.T) {
+	plaintext := "MEET AT THE OLD BRIDGE AT MIDNIGHT"
+	running_key := "THE FOREST WAS DARK AND QUIET EXCEPT FOR THE WIND IN THE TREES"
+	_, recovered := round_trip_cipher(t, &RunningKey{}, plaintext, running_key)
+
+	if recovered != plaintext {
+		t.Errorf("recovered = %q, want %q", recovered, plaintext)
+	}
+}
+
+func TestRunningKeyRejectsExhaustedKey(t *testing.T) {
+	var cipher RunningKey
+	if err := cipher.generate(ALPHABET); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	var out strings.Builder
+	err := cipher.encrypt_stream(strings.NewReader("THIS PLAINTEXT IS LONGER THAN THE KEY"), &out, "SHORT", nil)
+	if err == nil {
+		t.Fatal("expected an error when the running key is shorter than the plaintext")
+	}
+}